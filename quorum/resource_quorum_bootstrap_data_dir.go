@@ -13,10 +13,19 @@ import (
 	"github.com/peterbourgon/mergemap"
 
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/hashdb"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 )
 
+// consensus engines that the vendored geth version can't yet serve state from
+// under the pathdb layout. Genesis configs carrying one of these keys must
+// stick with the legacy hashdb `state_scheme`.
+var stateSchemeIncompatibleEngines = []string{"istanbul", "ibft2", "qbft"}
+
 // Use this resource to create a data dir locally. This equivalent to execute `geth init`.
 func resourceBootstrapDataDir() *schema.Resource {
 	return &schema.Resource{
@@ -53,6 +62,27 @@ func resourceBootstrapDataDir() *schema.Resource {
 					return
 				},
 			},
+			"cache_preimages": {
+				Type:        schema.TypeBool,
+				Description: "Enable recording of SHA3/keccak preimages in the state database so that `debug_traceTransaction`/`debug_storageRangeAt` can be used against the bootstrapped chain later on. Default is `false`",
+				Optional:    true,
+				ForceNew:    true,
+				Default:     false,
+			},
+			"state_scheme": {
+				Type:        schema.TypeString,
+				Description: "State scheme used to persist the trie, either `hash` for the legacy hashdb layout or `path` for the newer pathdb layout. Default is `hash`",
+				Optional:    true,
+				ForceNew:    true,
+				Default:     "hash",
+				ValidateFunc: func(i interface{}, s string) (ws []string, es []error) {
+					v := i.(string)
+					if v != "hash" && v != "path" {
+						es = append(es, fmt.Errorf("state_scheme must be either %q or %q, got %q", "hash", "path", v))
+					}
+					return
+				},
+			},
 			"data_dir_abs": {
 				Type:        schema.TypeString,
 				Description: "Absolute path to the data dir",
@@ -94,7 +124,41 @@ func resourceBootstrapDataDirCreate(d *schema.ResourceData, rawConfigurer interf
 	if err := json.Unmarshal(genesisJson, &miniGenesis); err != nil {
 		return err
 	}
-	// init datadir
+	genesis.UsePreimages = d.Get("cache_preimages").(bool)
+	stateScheme := d.Get("state_scheme").(string)
+	if err := validateStateScheme(stateScheme, miniGenesis.Config); err != nil {
+		return err
+	}
+	if err := initGenesisDataDir(nodeConfig, genesis, miniGenesis.Config, stateScheme); err != nil {
+		return err
+	}
+	_ = d.Set("data_dir_abs", absDir)
+	d.SetId(fmt.Sprintf("%d", time.Now().UnixNano()))
+	return nil
+}
+
+// validateStateScheme rejects a pathdb state_scheme when the genesis config
+// carries a consensus engine the vendored geth version can't yet serve under
+// pathdb.
+func validateStateScheme(stateScheme string, genesisConfig map[string]interface{}) error {
+	if stateScheme != "path" {
+		return nil
+	}
+	for _, engine := range stateSchemeIncompatibleEngines {
+		if _, ok := genesisConfig[engine]; ok {
+			return fmt.Errorf("state_scheme \"path\" is not supported for consensus engine %q", engine)
+		}
+	}
+	return nil
+}
+
+// initGenesisDataDir initializes the `chaindata`/`lightchaindata` databases
+// under nodeConfig.DataDir with genesis, committing its trie under the
+// requested stateScheme layout, and stores the merged raw ChainConfig
+// alongside it. It is shared by resourceBootstrapDataDir and
+// resourceBootstrapNetwork so both resources bootstrap a data dir the exact
+// same way.
+func initGenesisDataDir(nodeConfig *node.Config, genesis *core.Genesis, genesisConfig map[string]interface{}, stateScheme string) error {
 	stack, err := node.New(nodeConfig)
 	if err != nil {
 		return err
@@ -104,12 +168,21 @@ func resourceBootstrapDataDirCreate(d *schema.ResourceData, rawConfigurer interf
 		if err != nil {
 			return fmt.Errorf("can't open database for %s due to %s", name, err)
 		}
-		savedChainConfig, blockHash, err := core.SetupGenesisBlock(chaindb, genesis)
+		// record the scheme marker first, then open the trie database with
+		// the matching config and hand it to SetupGenesisBlock, so the
+		// genesis trie is committed under the same layout the marker names.
+		rawdb.WriteStateScheme(chaindb, stateScheme)
+		trieDB := triedb.NewDatabase(chaindb, stateSchemeConfig(stateScheme))
+		savedChainConfig, blockHash, err := core.SetupGenesisBlock(chaindb, trieDB, genesis)
 		if err != nil {
+			trieDB.Close()
 			return fmt.Errorf("can't setup genesis for %s due to %s", name, err)
 		}
+		if err := trieDB.Close(); err != nil {
+			return fmt.Errorf("can't close trie database for %s due to %s", name, err)
+		}
 		// let's merge the ChainConfig and save into the database
-		mergedChainConfig, err := merge(savedChainConfig, miniGenesis.Config)
+		mergedChainConfig, err := merge(savedChainConfig, genesisConfig)
 		if err != nil {
 			return fmt.Errorf("can't merge ChainConfig due to %v", err)
 		}
@@ -117,13 +190,21 @@ func resourceBootstrapDataDirCreate(d *schema.ResourceData, rawConfigurer interf
 		if err := chaindb.Put(append([]byte("ethereum-config-"), blockHash.Bytes()...), mergedChainConfig); err != nil {
 			return err
 		}
-		log.Printf("[DEBUG] Successfully wrote genesis state: database=%s, dir=%s", name, absDir)
+		log.Printf("[DEBUG] Successfully wrote genesis state: database=%s, dir=%s, state_scheme=%s", name, nodeConfig.DataDir, stateScheme)
 	}
-	_ = d.Set("data_dir_abs", absDir)
-	d.SetId(fmt.Sprintf("%d", time.Now().UnixNano()))
 	return nil
 }
 
+// stateSchemeConfig builds the triedb.Config matching the requested
+// state_scheme so the genesis trie is actually committed under that layout,
+// not just tagged with it after the fact.
+func stateSchemeConfig(stateScheme string) *triedb.Config {
+	if stateScheme == "path" {
+		return &triedb.Config{PathDB: pathdb.Defaults}
+	}
+	return &triedb.Config{HashDB: hashdb.Defaults}
+}
+
 func merge(config *params.ChainConfig, dst map[string]interface{}) ([]byte, error) {
 	// convert to map[string]interface{}
 	configData, err := json.Marshal(config)
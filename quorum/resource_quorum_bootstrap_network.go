@@ -0,0 +1,314 @@
+package quorum
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/peterbourgon/mergemap"
+)
+
+// istanbulExtraVanity and istanbulEpoch mirror the constants istanbul-tools
+// bakes into the genesis it generates.
+const (
+	istanbulExtraVanity = 32
+	istanbulEpoch       = 30000
+	cliquePeriod        = 5
+	cliqueEpoch         = 30000
+)
+
+// consensus engines supported by resourceBootstrapNetwork today.
+var supportedNetworkConsensus = []string{"ibft", "qbft", "raft", "clique"}
+
+// Use this resource to bootstrap a whole N-validator network in one shot:
+// generate one nodekey per validator, build a single genesis with the
+// validator set baked into `extraData`, and initialize one data dir per
+// validator, all via the same genesis setup code path used by
+// `quorum_bootstrap_data_dir`.
+func resourceBootstrapNetwork() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceBootstrapNetworkCreate,
+		Read:   resourceBootstrapNetworkRead,
+		Delete: resourceBootstrapNetworkDelete,
+
+		Schema: map[string]*schema.Schema{
+			"data_dir": {
+				Type:        schema.TypeString,
+				Description: "Base directory under which one data dir per validator is created",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"num_validators": {
+				Type:        schema.TypeInt,
+				Description: "Number of validators to generate and bootstrap",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"consensus": {
+				Type:        schema.TypeString,
+				Description: "Consensus engine to bake into the genesis. One of `ibft`, `qbft`, `raft`, `clique`",
+				Required:    true,
+				ForceNew:    true,
+				ValidateFunc: func(i interface{}, s string) (ws []string, es []error) {
+					v := i.(string)
+					for _, c := range supportedNetworkConsensus {
+						if v == c {
+							return
+						}
+					}
+					es = append(es, fmt.Errorf("consensus must be one of %v, got %q", supportedNetworkConsensus, v))
+					return
+				},
+			},
+			"chain_id": {
+				Type:        schema.TypeInt,
+				Description: "Chain id to put into the genesis ChainConfig. Default is `1337`",
+				Optional:    true,
+				ForceNew:    true,
+				Default:     1337,
+			},
+			"genesis_extra": {
+				Type:        schema.TypeString,
+				Description: "Additional genesis fields/ChainConfig overrides in JSON format, merged on top of the generated genesis",
+				Optional:    true,
+				ForceNew:    true,
+			},
+			"validators": {
+				Type:        schema.TypeList,
+				Description: "One entry per generated validator",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"enode": {
+							Type:        schema.TypeString,
+							Description: "enode:// URL of the validator, without an IP/port suffix",
+							Computed:    true,
+						},
+						"data_dir_abs": {
+							Type:        schema.TypeString,
+							Description: "Absolute path to the validator's data dir",
+							Computed:    true,
+						},
+						"nodekey_path": {
+							Type:        schema.TypeString,
+							Description: "Absolute path to the validator's nodekey file",
+							Computed:    true,
+						},
+					},
+				},
+			},
+			"genesis_json": {
+				Type:        schema.TypeString,
+				Description: "The generated genesis, in JSON format",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+type bootstrapValidator struct {
+	nodeKey *ecdsa.PrivateKey
+	address common.Address
+	dataDir string
+}
+
+func resourceBootstrapNetworkCreate(d *schema.ResourceData, rawConfigurer interface{}) error {
+	config := rawConfigurer.(*configurer)
+	config.bootstrapDataDirMux.Lock()
+	defer config.bootstrapDataDirMux.Unlock()
+
+	baseDir, err := createDirectory(d.Get("data_dir").(string))
+	if err != nil {
+		return err
+	}
+	numValidators := d.Get("num_validators").(int)
+	consensus := d.Get("consensus").(string)
+
+	validators := make([]*bootstrapValidator, numValidators)
+	addresses := make([]common.Address, numValidators)
+	for i := 0; i < numValidators; i++ {
+		nodeKey, err := crypto.GenerateKey()
+		if err != nil {
+			return fmt.Errorf("can't generate nodekey for validator %d due to %s", i, err)
+		}
+		dataDir, err := createDirectory(path.Join(baseDir, fmt.Sprintf("validator%d", i)))
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(path.Dir(nodeKeyPath(dataDir)), 0700); err != nil {
+			return err
+		}
+		if err := crypto.SaveECDSA(nodeKeyPath(dataDir), nodeKey); err != nil {
+			return fmt.Errorf("can't write nodekey for validator %d due to %s", i, err)
+		}
+		validators[i] = &bootstrapValidator{
+			nodeKey: nodeKey,
+			address: crypto.PubkeyToAddress(nodeKey.PublicKey),
+			dataDir: dataDir,
+		}
+		addresses[i] = validators[i].address
+	}
+
+	genesis, err := buildNetworkGenesis(consensus, d.Get("chain_id").(int), addresses)
+	if err != nil {
+		return err
+	}
+	if extra := d.Get("genesis_extra").(string); extra != "" {
+		var overrides map[string]interface{}
+		if err := json.Unmarshal([]byte(extra), &overrides); err != nil {
+			return fmt.Errorf("can't parse genesis_extra due to %s", err)
+		}
+		genesis, err = mergeGenesis(genesis, overrides)
+		if err != nil {
+			return err
+		}
+	}
+	genesisJson, err := json.Marshal(genesis)
+	if err != nil {
+		return err
+	}
+	var miniGenesis struct {
+		Config map[string]interface{} `json:"config"`
+	}
+	if err := json.Unmarshal(genesisJson, &miniGenesis); err != nil {
+		return err
+	}
+
+	validatorsOut := make([]map[string]interface{}, numValidators)
+	for i, v := range validators {
+		nodeConfig := &node.DefaultConfig
+		nodeConfig.DataDir = v.dataDir
+		nodeConfig.Name = "geth"
+		if err := initGenesisDataDir(nodeConfig, genesis, miniGenesis.Config, "hash"); err != nil {
+			return fmt.Errorf("can't bootstrap data dir for validator %d due to %s", i, err)
+		}
+		validatorsOut[i] = map[string]interface{}{
+			"enode":        enode.NewV4(&v.nodeKey.PublicKey, nil, 0, 0).URLv4(),
+			"data_dir_abs": v.dataDir,
+			"nodekey_path": nodeKeyPath(v.dataDir),
+		}
+	}
+
+	_ = d.Set("validators", validatorsOut)
+	_ = d.Set("genesis_json", string(genesisJson))
+	d.SetId(fmt.Sprintf("%d", time.Now().UnixNano()))
+	return nil
+}
+
+// buildNetworkGenesis constructs a genesis with the requested consensus
+// engine's ChainConfig and, for the istanbul-family engines, the validator
+// set baked into extraData the same way istanbul-tools' `container.Blockchain`
+// does it.
+func buildNetworkGenesis(consensus string, chainID int, validators []common.Address) (*core.Genesis, error) {
+	config := &params.ChainConfig{ChainID: big.NewInt(int64(chainID))}
+	genesis := &core.Genesis{
+		Config:     config,
+		Difficulty: big.NewInt(1),
+		GasLimit:   0x47b760,
+		Alloc:      core.GenesisAlloc{},
+	}
+	for _, addr := range validators {
+		genesis.Alloc[addr] = core.GenesisAccount{Balance: big.NewInt(0)}
+	}
+	switch consensus {
+	case "ibft":
+		config.Istanbul = &params.IstanbulConfig{Epoch: istanbulEpoch, ProposerPolicy: 0}
+		extraData, err := buildIstanbulExtraData(validators)
+		if err != nil {
+			return nil, err
+		}
+		genesis.ExtraData = extraData
+	case "qbft":
+		config.QBFT = &params.QBFTConfig{Epoch: istanbulEpoch, ProposerPolicy: 0}
+		extraData, err := buildIstanbulExtraData(validators)
+		if err != nil {
+			return nil, err
+		}
+		genesis.ExtraData = extraData
+	case "clique":
+		config.Clique = &params.CliqueConfig{Period: cliquePeriod, Epoch: cliqueEpoch}
+		genesis.ExtraData = buildCliqueExtraData(validators)
+	case "raft":
+		// raft has no notion of a validator set baked into the genesis
+	}
+	return genesis, nil
+}
+
+// buildIstanbulExtraData bakes the initial validator set into extraData the
+// way istanbul-tools' `extra.Encode` does: istanbulExtraVanity bytes of vanity
+// data followed by the RLP-encoded
+// `types.IstanbulExtra{Validators: validators, Seal: make([]byte, 65), CommittedSeal: [][]byte{}}`.
+func buildIstanbulExtraData(validators []common.Address) ([]byte, error) {
+	payload, err := rlp.EncodeToBytes(&types.IstanbulExtra{
+		Validators:    validators,
+		Seal:          make([]byte, 65),
+		CommittedSeal: [][]byte{},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't RLP-encode istanbul extraData due to %s", err)
+	}
+	return append(make([]byte, istanbulExtraVanity), payload...), nil
+}
+
+// buildCliqueExtraData bakes the initial signer set into extraData the way
+// clique expects: 32 bytes of vanity, one address per signer, and a 65-byte
+// zeroed seal.
+func buildCliqueExtraData(signers []common.Address) []byte {
+	extraData := make([]byte, 32)
+	for _, addr := range signers {
+		extraData = append(extraData, addr.Bytes()...)
+	}
+	return append(extraData, make([]byte, 65)...)
+}
+
+// mergeGenesis deep-merges genesis_extra on top of the generated genesis
+// using mergemap.Merge, the same tool resourceBootstrapDataDir's merge() uses
+// for ChainConfig. A naive top-level overwrite would let a genesis_extra
+// that only wants to tweak one ChainConfig field (the documented use case)
+// silently wipe out the whole "config" object buildNetworkGenesis just built.
+func mergeGenesis(genesis *core.Genesis, overrides map[string]interface{}) (*core.Genesis, error) {
+	data, err := json.Marshal(genesis)
+	if err != nil {
+		return nil, err
+	}
+	var base map[string]interface{}
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, err
+	}
+	mergedJson, err := json.Marshal(mergemap.Merge(base, overrides))
+	if err != nil {
+		return nil, err
+	}
+	var merged *core.Genesis
+	if err := json.Unmarshal(mergedJson, &merged); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+func nodeKeyPath(dataDir string) string {
+	return path.Join(dataDir, "geth", "nodekey")
+}
+
+func resourceBootstrapNetworkRead(_ *schema.ResourceData, _ interface{}) error {
+	return nil
+}
+
+func resourceBootstrapNetworkDelete(d *schema.ResourceData, _ interface{}) error {
+	d.SetId("")
+	dir := d.Get("data_dir").(string)
+	return os.RemoveAll(dir)
+}
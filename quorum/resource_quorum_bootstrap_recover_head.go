@@ -0,0 +1,146 @@
+package quorum
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// Use this resource to rewind/repair a data dir's HEAD pointer to a known-good
+// block after a bad-block incident, the same way the `blockRecovery` tooling
+// in older geth versions did: look up the canonical block by number or hash
+// and rewrite the head block/header/fast-block pointers to it.
+func resourceBootstrapRecoverHead() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceBootstrapRecoverHeadCreate,
+		Read:   resourceBootstrapRecoverHeadRead,
+		Delete: resourceBootstrapRecoverHeadDelete,
+
+		Schema: map[string]*schema.Schema{
+			"data_dir": {
+				Type:        schema.TypeString,
+				Description: "Data dir previously initialized by quorum_bootstrap_data_dir",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"instance_name": {
+				Type:        schema.TypeString,
+				Description: "The instance name of the node. This must be the same as the value in geth node config. Default is `geth`",
+				Optional:    true,
+				ForceNew:    true,
+				Default:     "geth",
+			},
+			"target_block_number": {
+				Type:          schema.TypeInt,
+				Description:   "Number of the block to rewind HEAD to. Conflicts with `target_block_hash`",
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"target_block_hash"},
+			},
+			"target_block_hash": {
+				Type:          schema.TypeString,
+				Description:   "Hash of the block to rewind HEAD to. Conflicts with `target_block_number`",
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"target_block_number"},
+			},
+		},
+	}
+}
+
+func resourceBootstrapRecoverHeadCreate(d *schema.ResourceData, rawConfigurer interface{}) error {
+	config := rawConfigurer.(*configurer)
+	config.bootstrapDataDirMux.Lock()
+	defer config.bootstrapDataDirMux.Unlock()
+
+	dataDir := d.Get("data_dir").(string)
+	instanceName := d.Get("instance_name").(string)
+
+	targetHash, hasHash := d.GetOk("target_block_hash")
+	targetNumber, hasNumber := d.GetOkExists("target_block_number")
+	if !hasHash && !hasNumber {
+		return fmt.Errorf("one of target_block_number or target_block_hash must be set")
+	}
+
+	if err := checkLevelDBUnlocked(filepath.Join(dataDir, instanceName, "chaindata")); err != nil {
+		return err
+	}
+
+	nodeConfig := &node.DefaultConfig
+	nodeConfig.DataDir = dataDir
+	nodeConfig.Name = instanceName
+	stack, err := node.New(nodeConfig)
+	if err != nil {
+		return err
+	}
+	chaindb, err := stack.OpenDatabase("chaindata", 0, 0)
+	if err != nil {
+		return fmt.Errorf("can't open chaindata due to %s", err)
+	}
+
+	var target common.Hash
+	if hasHash {
+		target = common.HexToHash(targetHash.(string))
+	} else {
+		number := uint64(targetNumber.(int))
+		target = rawdb.ReadCanonicalHash(chaindb, number)
+		if target == (common.Hash{}) {
+			return fmt.Errorf("no canonical block found at number %d", number)
+		}
+	}
+
+	headerNumber := rawdb.ReadHeaderNumber(chaindb, target)
+	if headerNumber == nil {
+		return fmt.Errorf("no header found for block %s in chaindata", target.Hex())
+	}
+	if rawdb.ReadCanonicalHash(chaindb, *headerNumber) != target {
+		return fmt.Errorf("block %s is not the canonical block at number %d", target.Hex(), *headerNumber)
+	}
+	block := rawdb.ReadBlock(chaindb, target, *headerNumber)
+	if block == nil {
+		return fmt.Errorf("block %s not found in chaindata", target.Hex())
+	}
+
+	rawdb.WriteHeadBlockHash(chaindb, block.Hash())
+	rawdb.WriteHeadHeaderHash(chaindb, block.Hash())
+	rawdb.WriteHeadFastBlockHash(chaindb, block.Hash())
+
+	d.SetId(strconv.FormatInt(time.Now().UnixNano(), 10))
+	return nil
+}
+
+// checkLevelDBUnlocked refuses to touch chaindata while a running geth
+// process is still holding the LevelDB lock, since recovering the head out
+// from under a live node would corrupt it. The directory is stat'd first so
+// a typo'd/never-bootstrapped data_dir fails with a clear error instead of
+// leveldb.OpenFile silently creating a brand-new empty database there.
+func checkLevelDBUnlocked(chaindataDir string) error {
+	if _, err := os.Stat(chaindataDir); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("chaindata directory [%s] does not exist", chaindataDir)
+		}
+		return err
+	}
+	db, err := leveldb.OpenFile(chaindataDir, nil)
+	if err != nil {
+		return fmt.Errorf("chaindata at [%s] appears to be locked by a running node: %s", chaindataDir, err)
+	}
+	return db.Close()
+}
+
+func resourceBootstrapRecoverHeadRead(_ *schema.ResourceData, _ interface{}) error {
+	return nil
+}
+
+func resourceBootstrapRecoverHeadDelete(d *schema.ResourceData, _ interface{}) error {
+	d.SetId("")
+	return nil
+}
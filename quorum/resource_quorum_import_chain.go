@@ -0,0 +1,250 @@
+package quorum
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	istanbulBackend "github.com/ethereum/go-ethereum/consensus/istanbul/backend"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// importBatchSize mirrors the batch size `geth import` streams blocks in.
+const importBatchSize = 2500
+
+// Use this resource to replay exported block RLP (as produced by `geth
+// export`) into a data dir that was already initialized by
+// `quorum_bootstrap_data_dir`. This is the Terraform equivalent of running
+// `geth import` once against the node's `chaindata`.
+func resourceImportChain() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceImportChainCreate,
+		Read:   resourceImportChainRead,
+		Delete: resourceImportChainDelete,
+
+		Schema: map[string]*schema.Schema{
+			"data_dir": {
+				Type:        schema.TypeString,
+				Description: "Data dir previously initialized by quorum_bootstrap_data_dir",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"instance_name": {
+				Type:        schema.TypeString,
+				Description: "The instance name of the node. This must be the same as the value in geth node config. Default is `geth`",
+				Optional:    true,
+				ForceNew:    true,
+				Default:     "geth",
+			},
+			"chain_import_files": {
+				Type:        schema.TypeList,
+				Description: "List of RLP-encoded block export files to import, in order. Each entry is a local path or an http(s) URL, optionally gzip-compressed",
+				Required:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"gc_mode": {
+				Type:        schema.TypeString,
+				Description: "State trie garbage collection mode to open the chaindata with, `full` or `archive`. Default is `full`",
+				Optional:    true,
+				ForceNew:    true,
+				Default:     "full",
+			},
+			"cache": {
+				Type:        schema.TypeInt,
+				Description: "Megabytes of memory allocated to internal caching while importing. Default is `1024`",
+				Optional:    true,
+				ForceNew:    true,
+				Default:     1024,
+			},
+			"head_block_number": {
+				Type:        schema.TypeInt,
+				Description: "Block number of the chain head after the import completed",
+				Computed:    true,
+			},
+			"head_block_hash": {
+				Type:        schema.TypeString,
+				Description: "Block hash of the chain head after the import completed",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func resourceImportChainCreate(d *schema.ResourceData, rawConfigurer interface{}) error {
+	config := rawConfigurer.(*configurer)
+	config.bootstrapDataDirMux.Lock()
+	defer config.bootstrapDataDirMux.Unlock()
+
+	nodeConfig := &node.DefaultConfig
+	nodeConfig.DataDir = d.Get("data_dir").(string)
+	nodeConfig.Name = d.Get("instance_name").(string)
+	stack, err := node.New(nodeConfig)
+	if err != nil {
+		return err
+	}
+	chaindb, err := stack.OpenDatabase("chaindata", d.Get("cache").(int), 0)
+	if err != nil {
+		return fmt.Errorf("can't open chaindata due to %s", err)
+	}
+
+	genesisHash := rawdb.ReadCanonicalHash(chaindb, 0)
+	chainConfig := rawdb.ReadChainConfig(chaindb, genesisHash)
+	if chainConfig == nil {
+		return fmt.Errorf("data dir [%s] has no genesis; run quorum_bootstrap_data_dir against it first", nodeConfig.DataDir)
+	}
+
+	cacheConfig := &core.CacheConfig{
+		TrieCleanLimit: 256,
+		TrieDirtyLimit: 256,
+		TrieTimeLimit:  5 * time.Minute,
+	}
+	if d.Get("gc_mode").(string) == "archive" {
+		cacheConfig.TrieDirtyDisabled = true
+	}
+	engine := buildConsensusEngine(chainConfig, chaindb)
+	blockchain, err := core.NewBlockChain(chaindb, cacheConfig, chainConfig, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		return fmt.Errorf("can't open blockchain due to %s", err)
+	}
+	defer blockchain.Stop()
+
+	for _, file := range d.Get("chain_import_files").([]interface{}) {
+		if err := importChainFile(file.(string), blockchain); err != nil {
+			return fmt.Errorf("can't import %s due to %s", file, err)
+		}
+	}
+
+	head := blockchain.CurrentBlock()
+	_ = d.Set("head_block_number", head.Number().Int64())
+	_ = d.Set("head_block_hash", head.Hash().Hex())
+	d.SetId(fmt.Sprintf("%d", time.Now().UnixNano()))
+	return nil
+}
+
+// buildConsensusEngine selects the consensus.Engine matching the chain's
+// saved ChainConfig: clique, istanbul or qbft when configured, and otherwise
+// ethash.NewFaker(), which does not verify proof-of-work. InsertChain calls
+// into the engine for every header/body it imports, so it can't be nil.
+func buildConsensusEngine(chainConfig *params.ChainConfig, chaindb ethdb.Database) consensus.Engine {
+	switch {
+	case chainConfig.Clique != nil:
+		return clique.New(chainConfig.Clique, chaindb)
+	case chainConfig.Istanbul != nil:
+		return istanbulBackend.New(&istanbul.Config{
+			Epoch:          chainConfig.Istanbul.Epoch,
+			ProposerPolicy: istanbul.ProposerPolicy(chainConfig.Istanbul.ProposerPolicy),
+		}, nil, chaindb)
+	case chainConfig.QBFT != nil:
+		return istanbulBackend.New(&istanbul.Config{
+			Epoch:          chainConfig.QBFT.Epoch,
+			ProposerPolicy: istanbul.ProposerPolicy(chainConfig.QBFT.ProposerPolicy),
+		}, nil, chaindb)
+	default:
+		return ethash.NewFaker()
+	}
+}
+
+// importChainFile streams RLP blocks from path (a local file path or an
+// http(s) URL, optionally gzip-compressed) and inserts them into blockchain
+// in batches, the same way `geth import` does.
+func importChainFile(path string, blockchain *core.BlockChain) error {
+	reader, err := openChainImportSource(path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	stream := rlp.NewStream(reader, 0)
+	batch := make(types.Blocks, 0, importBatchSize)
+	imported := 0
+	for {
+		var block types.Block
+		if err := stream.Decode(&block); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		batch = append(batch, &block)
+		if len(batch) >= importBatchSize {
+			if _, err := blockchain.InsertChain(batch); err != nil {
+				return err
+			}
+			imported += len(batch)
+			log.Printf("[DEBUG] Imported %d blocks from %s", imported, path)
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		if _, err := blockchain.InsertChain(batch); err != nil {
+			return err
+		}
+		imported += len(batch)
+	}
+	log.Printf("[DEBUG] Finished importing %d blocks from %s", imported, path)
+	return nil
+}
+
+func openChainImportSource(path string) (io.ReadCloser, error) {
+	var reader io.ReadCloser
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		resp, err := http.Get(path)
+		if err != nil {
+			return nil, err
+		}
+		reader = resp.Body
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		reader = f
+	}
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			reader.Close()
+			return nil, err
+		}
+		return gzipReadCloser{gz, reader}, nil
+	}
+	return reader, nil
+}
+
+// gzipReadCloser closes both the gzip reader and the underlying source
+// (file or HTTP body) it was wrapping.
+type gzipReadCloser struct {
+	*gzip.Reader
+	under io.ReadCloser
+}
+
+func (g gzipReadCloser) Close() error {
+	_ = g.Reader.Close()
+	return g.under.Close()
+}
+
+func resourceImportChainRead(_ *schema.ResourceData, _ interface{}) error {
+	return nil
+}
+
+func resourceImportChainDelete(d *schema.ResourceData, _ interface{}) error {
+	d.SetId("")
+	return nil
+}